@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/urfave/cli/v2"
+)
+
+var log = logging.Logger("main")
+
+// Commands are lotus-miner's top-level CLI subcommands.
+var Commands = []*cli.Command{
+	sturdyCmd,
+}
+
+func main() {
+	app := &cli.App{
+		Name:     "lotus-miner",
+		Usage:    "Filecoin decentralized storage network miner",
+		Commands: Commands,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Errorw("lotus-miner failed", "error", err)
+		os.Exit(1)
+	}
+}
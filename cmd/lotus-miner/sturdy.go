@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/sturdy/sturdydb"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// sturdyCmd exposes sturdydb's migration tooling so operators can inspect
+// and drive schema migrations outside of node startup (config.SturdyDB.AutoMigrate).
+// It's registered in main.go's Commands.
+var sturdyCmd = &cli.Command{
+	Name:  "sturdy",
+	Usage: "Manage the sturdydb-backed sealing pipeline database",
+	Subcommands: []*cli.Command{
+		sturdyMigrateCmd,
+	},
+}
+
+var sturdyMigrateCmd = &cli.Command{
+	Name:  "migrate",
+	Usage: "Inspect or drive sturdydb schema migrations",
+	Subcommands: []*cli.Command{
+		sturdyMigrateStatusCmd,
+		sturdyMigrateUpCmd,
+		sturdyMigrateDownCmd,
+		sturdyMigrateRedoCmd,
+	},
+}
+
+var sturdyFlags = []cli.Flag{
+	&cli.StringFlag{Name: "driver", Usage: "sturdydb driver (yugabyte, postgres, cockroach, sqlite)"},
+	&cli.StringSliceFlag{Name: "host", Usage: "database host(s), repeatable"},
+	&cli.StringFlag{Name: "port"},
+	&cli.StringFlag{Name: "username"},
+	&cli.StringFlag{Name: "password"},
+	&cli.StringFlag{Name: "database"},
+}
+
+func sturdyConfigFromFlags(cctx *cli.Context) config.SturdyDB {
+	return config.SturdyDB{
+		Driver:   cctx.String("driver"),
+		Hosts:    cctx.StringSlice("host"),
+		Port:     cctx.String("port"),
+		Username: cctx.String("username"),
+		Password: cctx.String("password"),
+		Database: cctx.String("database"),
+	}
+}
+
+func openSturdyForCLI(cctx *cli.Context) (*sturdydb.DB, error) {
+	db, err := sturdydb.NewFromConfig(sturdyConfigFromFlags(cctx))
+	if err != nil {
+		return nil, xerrors.Errorf("connecting to sturdydb: %w", err)
+	}
+	return db, nil
+}
+
+var sturdyMigrateStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "List registered migrations and whether each has been applied",
+	Flags: sturdyFlags,
+	Action: func(cctx *cli.Context) error {
+		db, err := openSturdyForCLI(cctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close() //nolint:errcheck
+
+		statuses, err := db.MigrateStatus(cctx.Context)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		return nil
+	},
+}
+
+var sturdyMigrateUpCmd = &cli.Command{
+	Name:  "up",
+	Usage: "Apply all pending migrations",
+	Flags: sturdyFlags,
+	Action: func(cctx *cli.Context) error {
+		db, err := openSturdyForCLI(cctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close() //nolint:errcheck
+
+		return db.Migrate(cctx.Context)
+	},
+}
+
+var sturdyMigrateDownCmd = &cli.Command{
+	Name:  "down",
+	Usage: "Roll back the most recently applied migration",
+	Flags: sturdyFlags,
+	Action: func(cctx *cli.Context) error {
+		db, err := openSturdyForCLI(cctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close() //nolint:errcheck
+
+		return db.MigrateDown(cctx.Context)
+	},
+}
+
+var sturdyMigrateRedoCmd = &cli.Command{
+	Name:  "redo",
+	Usage: "Roll back the most recently applied migration, then apply all pending migrations",
+	Flags: sturdyFlags,
+	Action: func(cctx *cli.Context) error {
+		db, err := openSturdyForCLI(cctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close() //nolint:errcheck
+
+		return db.MigrateRedo(cctx.Context)
+	},
+}
@@ -3,45 +3,51 @@ package itests
 import (
 	"context"
 	"crypto/sha256"
+	"embed"
 	"encoding/hex"
 	"fmt"
 	"testing"
 
 	"github.com/filecoin-project/lotus/itests/kit"
 	"github.com/filecoin-project/lotus/lib/sturdy/sturdydb"
+	"github.com/filecoin-project/lotus/lib/sturdy/sturdydb/migrate"
 	"github.com/filecoin-project/lotus/node"
 	"github.com/filecoin-project/lotus/node/config"
 )
 
-func staticConfig() config.SturdyDB {
-	return config.SturdyDB{
-		Hosts:    []string{"127.0.0.1"},
-		Username: "yugabyte",
-		Password: "yugabyte",
-		Database: "yugabyte",
-		Port:     "5433",
-		ITest:    sturdydb.ITestNewID(),
-	}
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+func init() {
+	migrate.Register(migrationsFS)
 }
+
 func withSetup(t *testing.T, f func(*kit.TestMiner)) {
+	// sqlite needs no running cluster, unlike the default YugabyteDB
+	// driver, and AutoMigrate creates itest_scratch instead of assuming it
+	// already exists.
+	testCfg := sturdydb.TestConfig(t)
+	testCfg.AutoMigrate = true
+
 	_, miner, _ := kit.EnsembleMinimal(t,
 		kit.LatestActorsAt(-1),
 		kit.MockProofs(),
 		kit.ConstructorOpts(
-			node.Override(new(config.SturdyDB), func() config.SturdyDB {
-				return staticConfig()
-			}),
-			node.Override(new(*sturdydb.DB), sturdydb.NewFromConfig), //Why does this not work?
+			node.Override(new(config.SturdyDB), func() config.SturdyDB { return testCfg }),
+			node.Override(new(*sturdydb.DB), sturdydb.NewFromConfig),
 		),
 	)
 
+	// kit.TestMiner doesn't expose the *sturdydb.DB its FX graph built, so
+	// tests need their own handle; it's the same config the graph used, so
+	// this is the same database, not a second one.
 	var err error
-	miner.SturdyDB, err = sturdydb.NewFromConfig(staticConfig())
+	miner.SturdyDB, err = sturdydb.NewFromConfig(testCfg)
 	if err != nil {
-		t.Fatal("Yugabyte connection error:", err)
+		t.Fatal("could not open test backend:", err)
 	}
 
-	defer miner.SturdyDB.ITestDeleteAll()
+	defer miner.SturdyDB.ITestDeleteAll() //nolint:errcheck
 	f(miner)
 }
 
@@ -136,6 +142,40 @@ func TestTransaction(t *testing.T) {
 	})
 }
 
+func TestSavepoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	withSetup(t, func(miner *kit.TestMiner) {
+		err := miner.SturdyDB.BeginTransactionOpts(ctx, sturdydb.DefaultTransactionOptions(), func(tx *sturdydb.Transaction) (commit bool) {
+			if err := tx.Exec(ctx, "INSERT INTO itest_scratch (some_int) VALUES (1), (2)"); err != nil {
+				t.Fatal("E0", err)
+			}
+			if err := tx.Savepoint("before_bad_insert"); err != nil {
+				t.Fatal("E1", err)
+			}
+			if err := tx.Exec(ctx, "INSERT INTO itest_scratch (some_int) VALUES (100)"); err != nil {
+				t.Fatal("E2", err)
+			}
+			if err := tx.RollbackTo("before_bad_insert"); err != nil {
+				t.Fatal("E3", err)
+			}
+			return true // commit the (1), (2) insert; the rolled-back (100) never lands
+		})
+		if err != nil {
+			t.Fatal("E4", err)
+		}
+
+		var sum int
+		if err := miner.SturdyDB.QueryRow(ctx, "SELECT SUM(some_int) FROM itest_scratch").Scan(&sum); err != nil {
+			t.Fatal("E5", err)
+		}
+		if sum != 1+2 {
+			t.Fatal("Expected 3, got ", sum)
+		}
+	})
+}
+
 func TestPartialWalk(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,103 @@
+package sturdydb
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// Row is the result of a single-row query, mirroring database/sql.Row.
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// Rows iterates over the results of a query, mirroring database/sql.Rows
+// with an added StructScan for mapping a row onto a `db`-tagged struct.
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	StructScan(dest any) error
+	Err() error
+	Close() error
+}
+
+// BackendTx is a single in-flight transaction on a Backend.
+type BackendTx interface {
+	Exec(ctx context.Context, sql string, args ...any) error
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) Row
+	Commit() error
+	Rollback() error
+}
+
+// Backend is the set of operations a sturdydb driver must implement. DB
+// delegates every call to the Backend selected by config.SturdyDB.Driver,
+// the same way lnd's kvdb package dispatches between its bbolt, postgres
+// and etcd drivers.
+type Backend interface {
+	Exec(ctx context.Context, sql string, args ...any) error
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) Row
+	Select(ctx context.Context, dest any, sql string, args ...any) error
+	BeginTransaction(ctx context.Context) (BackendTx, error)
+
+	// TryAdvisoryLock attempts to acquire a process-coordination lock
+	// keyed by key, used by DB.Migrate to keep multiple Lotus nodes from
+	// racing each other's migrations. Backends without a real equivalent
+	// may no-op and always succeed.
+	TryAdvisoryLock(ctx context.Context, key int64) (locked bool, release func() error, err error)
+
+	// SupportsIsolationLevels reports whether the backend honors
+	// BeginTransactionOpts' TransactionOptions.IsolationLevel via a `SET
+	// TRANSACTION ISOLATION LEVEL` statement. sqlite doesn't: it runs every
+	// transaction SERIALIZABLE and errors on the statement, so
+	// runTransaction skips it entirely rather than sending SQL the backend
+	// can't execute.
+	SupportsIsolationLevels() bool
+
+	Close() error
+}
+
+// BackendFactory opens a Backend from the given config. Drivers register
+// one via RegisterBackend, typically from an init() in their own file.
+type BackendFactory func(ctx context.Context, cfg config.SturdyDB) (Backend, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes a driver available under name for
+// config.SturdyDB.Driver to select. It is expected to be called from an
+// init() function, and panics on a duplicate registration.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if _, dup := backends[name]; dup {
+		panic("sturdydb: RegisterBackend called twice for driver " + name)
+	}
+	backends[name] = factory
+}
+
+// defaultDriver is used when config.SturdyDB.Driver is empty, so that
+// deployments predating the Driver field keep talking to YugabyteDB.
+const defaultDriver = "yugabyte"
+
+func openBackend(ctx context.Context, cfg config.SturdyDB) (Backend, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = defaultDriver
+	}
+
+	backendsMu.RLock()
+	factory, ok := backends[driver]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, xerrors.Errorf("sturdydb: unknown driver %q (missing import?)", driver)
+	}
+
+	return factory(ctx, cfg)
+}
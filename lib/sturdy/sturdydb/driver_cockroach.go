@@ -0,0 +1,17 @@
+package sturdydb
+
+import (
+	"context"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func init() {
+	// CockroachDB speaks the same wire protocol as Postgres and Yugabyte,
+	// so it shares postgresDSN and the generic sqlBackend; it's only
+	// registered separately so operators can say so explicitly in config
+	// and so future Cockroach-specific quirks have somewhere to live.
+	RegisterBackend("cockroach", func(ctx context.Context, cfg config.SturdyDB) (Backend, error) {
+		return openSQLBackend("pgx", postgresDSN(cfg), dialectPostgres)
+	})
+}
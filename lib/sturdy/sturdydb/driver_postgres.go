@@ -0,0 +1,13 @@
+package sturdydb
+
+import (
+	"context"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func init() {
+	RegisterBackend("postgres", func(ctx context.Context, cfg config.SturdyDB) (Backend, error) {
+		return openSQLBackend("pgx", postgresDSN(cfg), dialectPostgres)
+	})
+}
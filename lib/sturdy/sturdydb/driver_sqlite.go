@@ -0,0 +1,36 @@
+package sturdydb
+
+import (
+	"context"
+	"fmt"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver, pure Go (no cgo)
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func init() {
+	RegisterBackend("sqlite", func(ctx context.Context, cfg config.SturdyDB) (Backend, error) {
+		dsn := cfg.Database
+		if dsn == "" {
+			dsn = ":memory:"
+		}
+		// journal_mode(wal) lets one connection read while another holds a
+		// write transaction open, instead of the default rollback-journal
+		// locking that would block/SQLITE_BUSY a concurrent reader; the
+		// busy_timeout is a second line of defense for whatever contention
+		// WAL doesn't avoid outright.
+		params := "_pragma=foreign_keys(1)&_pragma=journal_mode(wal)&_pragma=busy_timeout(5000)"
+		// An in-memory database is private to the connection that opened it
+		// unless it uses cache=shared, and database/sql pools several
+		// connections, so only ":memory:" gets a shared cache. A file
+		// database doesn't: a shared cache locks whole tables between its
+		// connections, which would fail that concurrent reader with
+		// SQLITE_LOCKED.
+		if dsn == ":memory:" {
+			params = "cache=shared&" + params
+		}
+		dsn = fmt.Sprintf("file:%s?%s", dsn, params)
+		return openSQLBackend("sqlite", dsn, dialectSQLite)
+	})
+}
@@ -0,0 +1,37 @@
+package sturdydb
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func init() {
+	RegisterBackend("yugabyte", func(ctx context.Context, cfg config.SturdyDB) (Backend, error) {
+		return openSQLBackend("pgx", postgresDSN(cfg), dialectPostgres)
+	})
+}
+
+// postgresDSN builds a libpq-style connection string shared by every driver
+// that speaks the Postgres wire protocol (YugabyteDB, Postgres, Cockroach).
+// It goes through net/url rather than string interpolation so a username,
+// password or database name containing reserved characters (@, :, /, ...)
+// doesn't get misparsed into the wrong host or database.
+func postgresDSN(cfg config.SturdyDB) string {
+	host := "127.0.0.1"
+	if len(cfg.Hosts) > 0 {
+		host = strings.Join(cfg.Hosts, ",")
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     host + ":" + cfg.Port,
+		Path:     "/" + cfg.Database,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
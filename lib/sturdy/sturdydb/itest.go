@@ -0,0 +1,26 @@
+package sturdydb
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+var itestCounter int64
+
+// ITestNewID returns a process-unique identifier for config.SturdyDB.ITest,
+// naming a per-test schema on a shared YugabyteDB/Postgres cluster.
+func ITestNewID() string {
+	return fmt.Sprintf("itest_%d", atomic.AddInt64(&itestCounter, 1))
+}
+
+// ITestDeleteAll drops the schema named by this DB's itest ID (with
+// CASCADE), if one was set. sturdydb does not create that schema or direct
+// queries into it. It's a no-op when no itest ID is set, as in production
+// and with TestConfig's sqlite databases, which isolate tests by file.
+func (db *DB) ITestDeleteAll() error {
+	if db.itest == "" {
+		return nil
+	}
+	return db.Exec(context.Background(), fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", db.itest))
+}
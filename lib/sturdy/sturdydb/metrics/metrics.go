@@ -0,0 +1,149 @@
+// Package metrics instruments lib/sturdy/sturdydb.DB's operations: an
+// OpenCensus measure per call (bucketed by operation and an optional
+// caller-supplied label), an OpenTelemetry span per call from otel's global
+// tracer provider, and WARN-level logging of slow queries. It has
+// no dependency on sturdydb, so sturdydb can call into it without a cycle.
+package metrics
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var log = logging.Logger("sturdydb/metrics")
+
+var (
+	// Operation tags a measurement with the DB method that produced it
+	// (exec, query, query_row, select).
+	Operation, _ = tag.NewKey("operation")
+
+	// Caller tags a measurement with an optional, caller-supplied label
+	// (see WithLabel) identifying the call site, since "operation" alone
+	// doesn't say which sealing/pipeline code path issued the query.
+	Caller, _ = tag.NewKey("caller")
+)
+
+var (
+	QueryDuration = stats.Float64("sturdy_query_duration_seconds", "Duration of a sturdydb call", stats.UnitSeconds)
+	Retries       = stats.Int64("sturdy_retries_total", "Transaction retries due to a retryable conflict", stats.UnitDimensionless)
+	Commits       = stats.Int64("sturdy_commits_total", "Transaction commits", stats.UnitDimensionless)
+	Rollbacks     = stats.Int64("sturdy_rollbacks_total", "Transaction rollbacks", stats.UnitDimensionless)
+	InFlightTxns  = stats.Int64("sturdy_inflight_transactions", "Transactions currently open", stats.UnitDimensionless)
+)
+
+// Views are the OpenCensus views for this package's measures. Callers
+// register these alongside the rest of Lotus's views, the same way every
+// other instrumented subsystem does.
+var Views = []*view.View{
+	{
+		Measure:     QueryDuration,
+		Aggregation: view.Distribution(0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10),
+		TagKeys:     []tag.Key{Operation, Caller},
+	},
+	{Measure: Retries, Aggregation: view.Count()},
+	{Measure: Commits, Aggregation: view.Count()},
+	{Measure: Rollbacks, Aggregation: view.Count()},
+	// InFlightTxns is recorded as +1/-1 deltas (RecordTxOpen/RecordTxClose),
+	// so the view must sum them into a running total; LastValue() would just
+	// show whichever delta was reported last.
+	{Measure: InFlightTxns, Aggregation: view.Sum()},
+}
+
+var registerOnce sync.Once
+
+// RegisterViews registers Views with OpenCensus's global view registry. It
+// is idempotent and safe to call from every DB opened in a process (e.g.
+// each NewFromConfig call), since only the first call actually registers
+// anything.
+func RegisterViews() error {
+	var err error
+	registerOnce.Do(func() {
+		err = view.Register(Views...)
+	})
+	return err
+}
+
+// DefaultSlowQueryThreshold is used when a DB isn't configured with one of
+// its own.
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// Redactor masks sensitive values (API tokens, deal proposal signatures,
+// ...) out of logged query args. A nil Redactor logs args unmodified.
+type Redactor func(args []any) []any
+
+// WithLabel attaches a caller-supplied label to ctx, recorded on every
+// measurement and span taken while it's in scope. Sealing/pipeline code
+// paths should call this once near where they open a DB call so operators
+// can tell which caller is behind a given latency or retry spike.
+func WithLabel(ctx context.Context, label string) context.Context {
+	ctx, _ = tag.New(ctx, tag.Upsert(Caller, label))
+	return ctx
+}
+
+// StartSpan starts an OpenTelemetry span for op if a tracer is registered,
+// returning a context carrying the span and a func to end it. It's always
+// safe to call: with no tracer configured, otel's default no-op tracer is
+// used and span operations are free.
+func StartSpan(ctx context.Context, op string) (context.Context, func()) {
+	ctx, span := otel.Tracer("sturdydb").Start(ctx, "sturdydb."+op)
+	return ctx, span.End
+}
+
+// Observe records QueryDuration for op, attaches attributes (and any error)
+// to the span already active in ctx, and logs the query at WARN if it ran
+// at or past slowThreshold.
+func Observe(ctx context.Context, op, sql string, args []any, start time.Time, rowCount int, err error, slowThreshold time.Duration, redact Redactor) {
+	dur := time.Since(start)
+
+	taggedCtx, tagErr := tag.New(ctx, tag.Upsert(Operation, op))
+	if tagErr == nil {
+		stats.Record(taggedCtx, QueryDuration.M(dur.Seconds()))
+	}
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("sturdy.operation", op),
+			attribute.String("sturdy.sql", truncate(sql, 2048)),
+			attribute.Int("sturdy.rows", rowCount),
+		)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+
+	if slowThreshold <= 0 {
+		slowThreshold = DefaultSlowQueryThreshold
+	}
+	if dur >= slowThreshold {
+		loggedArgs := args
+		if redact != nil {
+			loggedArgs = redact(args)
+		}
+		log.Warnw("slow sturdydb query", "operation", op, "duration", dur, "sql", sql, "args", loggedArgs, "error", err, "stack", string(debug.Stack()))
+	}
+}
+
+func RecordRetry(ctx context.Context)    { stats.Record(ctx, Retries.M(1)) }
+func RecordCommit(ctx context.Context)   { stats.Record(ctx, Commits.M(1)) }
+func RecordRollback(ctx context.Context) { stats.Record(ctx, Rollbacks.M(1)) }
+func RecordTxOpen(ctx context.Context)   { stats.Record(ctx, InFlightTxns.M(1)) }
+func RecordTxClose(ctx context.Context)  { stats.Record(ctx, InFlightTxns.M(-1)) }
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,186 @@
+package sturdydb
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/sturdy/sturdydb/migrate"
+)
+
+// migrationLockKey is the pg_advisory_lock key sturdydb uses so multiple
+// Lotus processes racing DB.Migrate against the same cluster serialize
+// instead of applying the same migration twice. Arbitrary but stable.
+const migrationLockKey = 0x53545244 // "STRD"
+
+const migrationsTableDDL = `
+CREATE TABLE IF NOT EXISTS sturdy_migrations (
+	version    bigint PRIMARY KEY,
+	name       text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	checksum   bytea NOT NULL
+)`
+
+// Migrate applies every pending migration registered via migrate.Register,
+// in version order, each inside its own transaction. It takes the
+// Backend's advisory lock for the duration so that multiple Lotus nodes
+// starting up against the same cluster don't race each other, and refuses
+// to start if an already-applied migration's file no longer matches the
+// checksum that was recorded when it ran.
+func (db *DB) Migrate(ctx context.Context) error {
+	if err := db.Exec(ctx, migrationsTableDDL); err != nil {
+		return xerrors.Errorf("sturdydb: creating migrations table: %w", err)
+	}
+
+	locked, release, err := db.backend.TryAdvisoryLock(ctx, migrationLockKey)
+	if err != nil {
+		return xerrors.Errorf("sturdydb: acquiring migration lock: %w", err)
+	}
+	if !locked {
+		return xerrors.Errorf("sturdydb: another process is already running migrations")
+	}
+	defer func() {
+		if err := release(); err != nil {
+			log.Errorw("sturdydb: releasing migration lock failed", "error", err)
+		}
+	}()
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrate.All() {
+		if prior, ok := applied[m.Version]; ok {
+			if prior != m.Checksum {
+				return xerrors.Errorf("sturdydb: migration %d_%s has changed since it was applied (checksum drift)", m.Version, m.Name)
+			}
+			continue
+		}
+
+		log.Infow("sturdydb: applying migration", "version", m.Version, "name", m.Name)
+		if err := db.applyMigration(ctx, m); err != nil {
+			return xerrors.Errorf("sturdydb: applying migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) applyMigration(ctx context.Context, m migrate.Migration) error {
+	var txErr error
+	err := db.BeginTransaction(ctx, func(tx *Transaction) (commit bool) {
+		if err := tx.Exec(ctx, m.Up); err != nil {
+			txErr = err
+			return false
+		}
+		if err := tx.Exec(ctx,
+			"INSERT INTO sturdy_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			m.Version, m.Name, m.Checksum[:],
+		); err != nil {
+			txErr = err
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return txErr
+}
+
+func (db *DB) appliedMigrations(ctx context.Context) (map[int64][32]byte, error) {
+	rows, err := db.Query(ctx, "SELECT version, checksum FROM sturdy_migrations")
+	if err != nil {
+		return nil, xerrors.Errorf("sturdydb: loading applied migrations: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	out := map[int64][32]byte{}
+	for rows.Next() {
+		var version int64
+		var checksum []byte
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, err
+		}
+		var sum [32]byte
+		copy(sum[:], checksum)
+		out[version] = sum
+	}
+	return out, rows.Err()
+}
+
+// MigrationStatus reports one registered migration alongside whether it has
+// been applied.
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// MigrateStatus reports every registered migration alongside whether it has
+// been applied, in version order.
+func (db *DB) MigrateStatus(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []MigrationStatus
+	for _, m := range migrate.All() {
+		_, ok := applied[m.Version]
+		out = append(out, MigrationStatus{Version: m.Version, Name: m.Name, Applied: ok})
+	}
+	return out, nil
+}
+
+// MigrateDown rolls back the most recently applied migration using its
+// "-- +down" section, and errors if it doesn't have one.
+func (db *DB) MigrateDown(ctx context.Context) error {
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	all := migrate.All()
+	var target *migrate.Migration
+	for i := range all {
+		if _, ok := applied[all[i].Version]; ok {
+			target = &all[i]
+		}
+	}
+	if target == nil {
+		return xerrors.Errorf("sturdydb: no applied migrations to roll back")
+	}
+	if target.Down == "" {
+		return xerrors.Errorf("sturdydb: migration %d_%s has no down section", target.Version, target.Name)
+	}
+
+	var txErr error
+	err = db.BeginTransaction(ctx, func(tx *Transaction) (commit bool) {
+		if err := tx.Exec(ctx, target.Down); err != nil {
+			txErr = err
+			return false
+		}
+		if err := tx.Exec(ctx, "DELETE FROM sturdy_migrations WHERE version = $1", target.Version); err != nil {
+			txErr = err
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return xerrors.Errorf("sturdydb: rolling back migration %d_%s: %w", target.Version, target.Name, err)
+	}
+	if txErr != nil {
+		return xerrors.Errorf("sturdydb: rolling back migration %d_%s: %w", target.Version, target.Name, txErr)
+	}
+	return nil
+}
+
+// MigrateRedo rolls back the most recently applied migration, then runs
+// Migrate, which reapplies it along with any other pending migrations.
+func (db *DB) MigrateRedo(ctx context.Context) error {
+	if err := db.MigrateDown(ctx); err != nil {
+		return err
+	}
+	return db.Migrate(ctx)
+}
@@ -0,0 +1,123 @@
+// Package migrate discovers schema migrations embedded by sturdydb-using
+// packages (storage/paths, sealing, ...) and hands sturdydb.DB a single,
+// version-ordered list to apply. It has no dependency on sturdydb itself so
+// that sturdydb can depend on it without an import cycle; actually applying
+// migrations against a live connection is sturdydb.DB.Migrate's job.
+package migrate
+
+import (
+	"crypto/sha256"
+	"embed"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// Migration is one version-numbered schema change, parsed from a single
+// embedded `<version>_<name>.sql` file.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // empty if the file had no "-- +down" section
+	Checksum [sha256.Size]byte
+}
+
+var filenameRe = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.sql$`)
+
+const downMarker = "-- +down"
+
+var (
+	registryMu sync.Mutex
+	registry   []Migration
+)
+
+// Register discovers every `<version>_<name>.sql` file under fsys, at any
+// depth, and adds it to the package-wide migration set. Each registering
+// package calls this from an init() with its own embed.FS, e.g.:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	func init() { migrate.Register(migrationsFS) }
+//
+// fsys is walked rather than read non-recursively because a go:embed
+// pattern like "migrations/*.sql" nests the matched files under a
+// "migrations" directory relative to fsys's root, not at the root itself.
+//
+// Every file under fsys is treated as a migration: one whose name doesn't
+// match `<version>_<name>.sql` is a startup-time panic.
+//
+// Versions must be unique across the whole process: two packages picking
+// the same version number is a startup-time panic, the same way a
+// duplicate sturdydb driver registration is.
+func Register(fsys embed.FS) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		m, err := parseMigration(fsys, p)
+		if err != nil {
+			return xerrors.Errorf("%s: %w", p, err)
+		}
+		for _, existing := range registry {
+			if existing.Version == m.Version {
+				return xerrors.Errorf("duplicate migration version %d (%s and %s)", m.Version, existing.Name, m.Name)
+			}
+		}
+		registry = append(registry, m)
+		return nil
+	})
+	if err != nil {
+		panic(xerrors.Errorf("sturdydb/migrate: %w", err))
+	}
+}
+
+func parseMigration(fsys embed.FS, filePath string) (Migration, error) {
+	match := filenameRe.FindStringSubmatch(path.Base(filePath))
+	if match == nil {
+		return Migration{}, xerrors.Errorf("filename must match <version>_<name>.sql, got %q", filePath)
+	}
+	version, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return Migration{}, xerrors.Errorf("parsing version: %w", err)
+	}
+
+	contents, err := fsys.ReadFile(filePath)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	up, down, _ := strings.Cut(string(contents), downMarker)
+
+	return Migration{
+		Version:  version,
+		Name:     match[2],
+		Up:       strings.TrimSpace(up),
+		Down:     strings.TrimSpace(down),
+		Checksum: sha256.Sum256(contents),
+	}, nil
+}
+
+// All returns every registered migration, sorted by version ascending.
+func All() []Migration {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	out := make([]Migration, len(registry))
+	copy(out, registry)
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"embed"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/valid/*.sql
+var validFS embed.FS
+
+//go:embed testdata/invalid/*.sql
+var invalidFS embed.FS
+
+//go:embed testdata/dup/*.sql
+var dupFS embed.FS
+
+// reset clears the package-wide registry so tests don't see migrations
+// left behind by an earlier test in the same run.
+func reset(t *testing.T) {
+	t.Helper()
+	registryMu.Lock()
+	registry = nil
+	registryMu.Unlock()
+}
+
+func TestRegisterAndAll(t *testing.T) {
+	reset(t)
+	Register(validFS)
+
+	all := All()
+	if len(all) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(all))
+	}
+	if all[0].Version != 1 || all[1].Version != 2 {
+		t.Fatalf("expected versions [1, 2] in order, got [%d, %d]", all[0].Version, all[1].Version)
+	}
+	if all[0].Name != "create_foo" {
+		t.Fatalf("expected name create_foo, got %q", all[0].Name)
+	}
+	if !strings.Contains(all[0].Up, "CREATE TABLE foo") {
+		t.Fatalf("unexpected up section: %q", all[0].Up)
+	}
+	if !strings.Contains(all[0].Down, "DROP TABLE foo") {
+		t.Fatalf("unexpected down section: %q", all[0].Down)
+	}
+	if all[1].Down != "" {
+		t.Fatalf("expected no down section for create_bar, got %q", all[1].Down)
+	}
+}
+
+func TestParseMigrationChecksum(t *testing.T) {
+	reset(t)
+	contents, err := validFS.ReadFile("testdata/valid/0001_create_foo.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := parseMigration(validFS, "testdata/valid/0001_create_foo.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(contents); m.Checksum != want {
+		t.Fatalf("checksum mismatch: got %x, want %x", m.Checksum, want)
+	}
+}
+
+func TestRegisterInvalidFilename(t *testing.T) {
+	reset(t)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on a malformed filename")
+		}
+	}()
+	Register(invalidFS)
+}
+
+func TestRegisterDuplicateVersion(t *testing.T) {
+	reset(t)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on a duplicate version")
+		}
+	}()
+	Register(dupFS)
+}
@@ -0,0 +1,27 @@
+package sturdydb
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	sqlstateSerializationFailure = "40001"
+	sqlstateDeadlockDetected     = "40P01"
+)
+
+// isRetryable reports whether err represents a transient serialization
+// failure or deadlock that YugabyteDB/Cockroach/Postgres raise under
+// contention, and that's safe to retry by re-running the transaction from
+// scratch.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case sqlstateSerializationFailure, sqlstateDeadlockDetected:
+			return true
+		}
+	}
+	return false
+}
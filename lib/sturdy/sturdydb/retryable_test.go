@@ -0,0 +1,31 @@
+package sturdydb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", fmt.Errorf("boom"), false},
+		{"serialization failure", &pgconn.PgError{Code: sqlstateSerializationFailure}, true},
+		{"deadlock detected", &pgconn.PgError{Code: sqlstateDeadlockDetected}, true},
+		{"unrelated pg error", &pgconn.PgError{Code: "23505"}, false},
+		{"wrapped serialization failure", fmt.Errorf("sturdydb: commit: %w", &pgconn.PgError{Code: sqlstateSerializationFailure}), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isRetryable(c.err); got != c.want {
+				t.Fatalf("isRetryable(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package sturdydb
+
+import "strings"
+
+// Savepoint marks a point within the current transaction that a later
+// RollbackTo can return to without losing the rest of the transaction's
+// work, for callers doing partial work inside a long transaction. It goes
+// through Exec, not the raw BackendTx, so it's covered by the same
+// metrics/tracing/slow-query logging as every other Transaction call.
+func (tx *Transaction) Savepoint(name string) error {
+	return tx.Exec(tx.ctx, "SAVEPOINT "+quoteIdent(name))
+}
+
+// RollbackTo undoes everything since the matching Savepoint call, without
+// rolling back the whole transaction. It does not clear a failed
+// statement: once any statement run against tx has failed,
+// BeginTransaction and BeginTransactionOpts roll back the whole
+// transaction and return that error, whether or not RollbackTo was called
+// after it.
+func (tx *Transaction) RollbackTo(name string) error {
+	return tx.Exec(tx.ctx, "ROLLBACK TO SAVEPOINT "+quoteIdent(name))
+}
+
+// Release forgets a savepoint that's no longer needed. It does not commit
+// or discard any work; it only frees the backend resources held for it.
+func (tx *Transaction) Release(name string) error {
+	return tx.Exec(tx.ctx, "RELEASE SAVEPOINT "+quoteIdent(name))
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,94 @@
+package sturdydb
+
+import (
+	"reflect"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+func structFieldByColumn(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			if tag == column {
+				return v.Field(i), true
+			}
+			continue
+		}
+		if strings.EqualFold(f.Name, column) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func assignScanned(fv reflect.Value, raw any) {
+	if raw == nil || !fv.CanSet() {
+		return
+	}
+	// Some drivers hand TEXT columns back as []byte; coerce to string when
+	// the destination field expects one.
+	if b, ok := raw.([]byte); ok && fv.Kind() == reflect.String {
+		fv.SetString(string(b))
+		return
+	}
+	rv := reflect.ValueOf(raw)
+	if rv.Type().ConvertibleTo(fv.Type()) {
+		fv.Set(rv.Convert(fv.Type()))
+	}
+}
+
+// scanRowInto maps one already-scanned row (columns alongside their scanned
+// values) onto dest, which must be a pointer to a struct. Columns with no
+// matching tagged/named field are skipped, and fields with no matching
+// column (e.g. the "Unpopulated" field exercised by the itests) are left at
+// their zero value.
+func scanRowInto(dest any, columns []string, values []any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return xerrors.Errorf("sturdydb: StructScan destination must be a non-nil pointer to a struct, got %T", dest)
+	}
+	sv := dv.Elem()
+	for i, col := range columns {
+		if fv, ok := structFieldByColumn(sv, col); ok {
+			assignScanned(fv, values[i])
+		}
+	}
+	return nil
+}
+
+// scanRowsInto drains rows into dest, which must be a pointer to a slice of
+// structs; it's the calling convention shared by every Backend's Select.
+func scanRowsInto(rows Rows, dest any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice {
+		return xerrors.Errorf("sturdydb: Select destination must be a pointer to a slice, got %T", dest)
+	}
+	sliceV := dv.Elem()
+	elemType := sliceV.Type().Elem()
+
+	for rows.Next() {
+		elemPtr := reflect.New(elemType)
+		if err := rows.StructScan(elemPtr.Interface()); err != nil {
+			return err
+		}
+		sliceV.Set(reflect.Append(sliceV, elemPtr.Elem()))
+	}
+	return rows.Err()
+}
+
+// reflectLen returns len(*dest) when dest is a pointer to a slice, and 0
+// otherwise. Used only to annotate tracing spans with an approximate row
+// count for Select calls.
+func reflectLen(dest any) int {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return 0
+	}
+	return v.Len()
+}
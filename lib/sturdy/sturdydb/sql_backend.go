@@ -0,0 +1,168 @@
+package sturdydb
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/xerrors"
+)
+
+// sqlDialect distinguishes the handful of behaviors that differ between the
+// postgres-wire drivers (YugabyteDB, Postgres, Cockroach) and sqlite, even
+// though both go through database/sql.
+type sqlDialect int
+
+const (
+	dialectPostgres sqlDialect = iota
+	dialectSQLite
+)
+
+// sqlBackend implements Backend on top of database/sql. It's shared by
+// every driver in this package: YugabyteDB, Postgres and Cockroach all
+// speak the same wire protocol via pgx's database/sql driver, and sqlite
+// has one too, so there's no need for a bespoke Backend per driver.
+type sqlBackend struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// SupportsIsolationLevels reports whether the dialect honors `SET
+// TRANSACTION ISOLATION LEVEL`. sqlite always runs SERIALIZABLE and errors
+// on the statement, so sturdydb must not send it.
+func (b *sqlBackend) SupportsIsolationLevels() bool {
+	return b.dialect == dialectPostgres
+}
+
+func openSQLBackend(driverName, dsn string, dialect sqlDialect) (Backend, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, xerrors.Errorf("sturdydb: opening %s: %w", driverName, err)
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("sturdydb: connecting via %s: %w", driverName, err)
+	}
+	return &sqlBackend{db: db, dialect: dialect}, nil
+}
+
+func (b *sqlBackend) Exec(ctx context.Context, query string, args ...any) error {
+	if _, err := b.db.ExecContext(ctx, query, args...); err != nil {
+		return xerrors.Errorf("sturdydb: exec: %w", err)
+	}
+	return nil
+}
+
+func (b *sqlBackend) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, xerrors.Errorf("sturdydb: query: %w", err)
+	}
+	return &sqlRows{rows: rows}, nil
+}
+
+func (b *sqlBackend) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return b.db.QueryRowContext(ctx, query, args...)
+}
+
+func (b *sqlBackend) Select(ctx context.Context, dest any, query string, args ...any) error {
+	rows, err := b.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close() //nolint:errcheck
+	return scanRowsInto(rows, dest)
+}
+
+func (b *sqlBackend) BeginTransaction(ctx context.Context) (BackendTx, error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("sturdydb: begin: %w", err)
+	}
+	return &sqlTx{tx: tx}, nil
+}
+
+func (b *sqlBackend) Close() error {
+	return b.db.Close()
+}
+
+// TryAdvisoryLock attempts to acquire a process-coordination lock keyed by
+// key. Postgres-wire backends use a real pg_advisory_lock, taken and
+// released on one dedicated connection since the lock belongs to the
+// session that took it; sqlite has no equivalent and always succeeds
+// without locking anything.
+func (b *sqlBackend) TryAdvisoryLock(ctx context.Context, key int64) (locked bool, release func() error, err error) {
+	if b.dialect != dialectPostgres {
+		return true, func() error { return nil }, nil
+	}
+
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return false, nil, xerrors.Errorf("sturdydb: advisory lock connection: %w", err)
+	}
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return false, nil, xerrors.Errorf("sturdydb: pg_try_advisory_lock: %w", err)
+	}
+	if !locked {
+		_ = conn.Close()
+		return false, nil, nil
+	}
+	return true, func() error {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+		if cErr := conn.Close(); err == nil {
+			err = cErr
+		}
+		return err
+	}, nil
+}
+
+type sqlRows struct {
+	rows *sql.Rows
+}
+
+func (r *sqlRows) Next() bool             { return r.rows.Next() }
+func (r *sqlRows) Scan(dest ...any) error { return r.rows.Scan(dest...) }
+func (r *sqlRows) Err() error             { return r.rows.Err() }
+func (r *sqlRows) Close() error           { return r.rows.Close() }
+
+func (r *sqlRows) StructScan(dest any) error {
+	cols, err := r.rows.Columns()
+	if err != nil {
+		return xerrors.Errorf("sturdydb: columns: %w", err)
+	}
+	values := make([]any, len(cols))
+	holders := make([]any, len(cols))
+	for i := range values {
+		holders[i] = &values[i]
+	}
+	if err := r.rows.Scan(holders...); err != nil {
+		return xerrors.Errorf("sturdydb: structscan: %w", err)
+	}
+	return scanRowInto(dest, cols, values)
+}
+
+type sqlTx struct {
+	tx *sql.Tx
+}
+
+func (t *sqlTx) Exec(ctx context.Context, query string, args ...any) error {
+	if _, err := t.tx.ExecContext(ctx, query, args...); err != nil {
+		return xerrors.Errorf("sturdydb: tx exec: %w", err)
+	}
+	return nil
+}
+
+func (t *sqlTx) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, xerrors.Errorf("sturdydb: tx query: %w", err)
+	}
+	return &sqlRows{rows: rows}, nil
+}
+
+func (t *sqlTx) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (t *sqlTx) Commit() error   { return t.tx.Commit() }
+func (t *sqlTx) Rollback() error { return t.tx.Rollback() }
@@ -0,0 +1,348 @@
+package sturdydb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/sturdy/sturdydb/metrics"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+var log = logging.Logger("sturdydb")
+
+// DB is a handle to the relational store backing Lotus's sealing-pipeline
+// state. It delegates every operation to the Backend chosen by
+// config.SturdyDB.Driver, so callers never need to know whether they're
+// talking to YugabyteDB, Postgres, Cockroach or, in tests, sqlite.
+type DB struct {
+	backend Backend
+	itest   string
+
+	slowThreshold time.Duration
+	redact        metrics.Redactor
+}
+
+// NewFromConfig opens the Backend named by cfg.Driver (defaulting to
+// "yugabyte" for backward compatibility with existing deployments) and
+// returns a DB wrapping it.
+func NewFromConfig(cfg config.SturdyDB) (*DB, error) {
+	if err := metrics.RegisterViews(); err != nil {
+		return nil, xerrors.Errorf("sturdydb: registering metrics views: %w", err)
+	}
+
+	backend, err := openBackend(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	slowThreshold := cfg.SlowQueryThreshold
+	if slowThreshold <= 0 {
+		slowThreshold = metrics.DefaultSlowQueryThreshold
+	}
+	db := &DB{backend: backend, itest: cfg.ITest, slowThreshold: slowThreshold}
+
+	if cfg.AutoMigrate {
+		if err := db.Migrate(context.Background()); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("sturdydb: automigrate: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// SetSlowQueryThreshold overrides the duration after which a query is
+// logged at WARN. The default is metrics.DefaultSlowQueryThreshold.
+func (db *DB) SetSlowQueryThreshold(d time.Duration) {
+	db.slowThreshold = d
+}
+
+// SetRedactor installs a function to mask sensitive query args (API
+// tokens, signatures, ...) before they're included in a slow-query log
+// line.
+func (db *DB) SetRedactor(r metrics.Redactor) {
+	db.redact = r
+}
+
+func (db *DB) Exec(ctx context.Context, sql string, args ...any) error {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "exec")
+	defer end()
+
+	err := db.backend.Exec(ctx, sql, args...)
+	metrics.Observe(ctx, "exec", sql, args, start, 0, err, db.slowThreshold, db.redact)
+	return err
+}
+
+func (db *DB) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "query")
+
+	rows, err := db.backend.Query(ctx, sql, args...)
+	if err != nil {
+		metrics.Observe(ctx, "query", sql, args, start, 0, err, db.slowThreshold, db.redact)
+		end()
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, db: db, ctx: ctx, end: end, sql: sql, args: args, start: start}, nil
+}
+
+func (db *DB) QueryRow(ctx context.Context, sql string, args ...any) Row {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "query_row")
+	row := db.backend.QueryRow(ctx, sql, args...)
+	return &instrumentedRow{Row: row, db: db, ctx: ctx, end: end, sql: sql, args: args, start: start}
+}
+
+func (db *DB) Select(ctx context.Context, dest any, sql string, args ...any) error {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "select")
+	defer end()
+
+	err := db.backend.Select(ctx, dest, sql, args...)
+	metrics.Observe(ctx, "select", sql, args, start, reflectLen(dest), err, db.slowThreshold, db.redact)
+	return err
+}
+
+// instrumentedRows wraps a Backend's Rows to record its duration, final
+// row count and error once the caller closes it.
+type instrumentedRows struct {
+	Rows
+	db    *DB
+	ctx   context.Context
+	end   func()
+	sql   string
+	args  []any
+	start time.Time
+	count int
+
+	// onErr, if set, is also told about the final error, so Transaction's
+	// wrapper can track the first failure across a whole transaction (see
+	// Transaction.setErr) rather than just whatever Commit() returns.
+	onErr func(error)
+}
+
+func (r *instrumentedRows) Next() bool {
+	ok := r.Rows.Next()
+	if ok {
+		r.count++
+	}
+	return ok
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	finalErr := err
+	if finalErr == nil {
+		finalErr = r.Rows.Err()
+	}
+	metrics.Observe(r.ctx, "query", r.sql, r.args, r.start, r.count, finalErr, r.db.slowThreshold, r.db.redact)
+	if r.onErr != nil {
+		r.onErr(finalErr)
+	}
+	r.end()
+	return err
+}
+
+// instrumentedRow wraps a Backend's Row to record its duration and error
+// once the caller calls Scan.
+type instrumentedRow struct {
+	Row
+	db    *DB
+	ctx   context.Context
+	end   func()
+	sql   string
+	args  []any
+	start time.Time
+
+	// onErr is Transaction's first-error tracker; see instrumentedRows.onErr.
+	onErr func(error)
+}
+
+func (r *instrumentedRow) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	rowCount := 1
+	if err != nil {
+		rowCount = 0
+	}
+	metrics.Observe(r.ctx, "query_row", r.sql, r.args, r.start, rowCount, err, r.db.slowThreshold, r.db.redact)
+	if r.onErr != nil {
+		r.onErr(err)
+	}
+	r.end()
+	return err
+}
+
+// Close releases the underlying Backend's resources.
+func (db *DB) Close() error {
+	return db.backend.Close()
+}
+
+// Transaction is a single in-flight transaction started by BeginTransaction.
+type Transaction struct {
+	tx  BackendTx
+	ctx context.Context
+	db  *DB
+
+	mu           sync.Mutex
+	statementErr error // first error raised by a statement run against tx, if any
+}
+
+// setErr records err as the transaction's firstErr if it's the first
+// failure seen. fn's signature (func(tx *Transaction) (commit bool)) gives
+// it no way to report a statement error itself, so runTransaction consults
+// this instead of only Commit()'s error — by the time a failed statement's
+// transaction reaches Commit, Postgres-family backends report it as simply
+// aborted, not as the original SQLSTATE isRetryable needs to see.
+func (tx *Transaction) setErr(err error) {
+	if err == nil {
+		return
+	}
+	tx.mu.Lock()
+	if tx.statementErr == nil {
+		tx.statementErr = err
+	}
+	tx.mu.Unlock()
+}
+
+func (tx *Transaction) firstErr() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.statementErr
+}
+
+func (tx *Transaction) Exec(ctx context.Context, sql string, args ...any) error {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "tx_exec")
+	defer end()
+
+	err := tx.tx.Exec(ctx, sql, args...)
+	metrics.Observe(ctx, "tx_exec", sql, args, start, 0, err, tx.db.slowThreshold, tx.db.redact)
+	tx.setErr(err)
+	return err
+}
+
+func (tx *Transaction) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "tx_query")
+
+	rows, err := tx.tx.Query(ctx, sql, args...)
+	if err != nil {
+		metrics.Observe(ctx, "tx_query", sql, args, start, 0, err, tx.db.slowThreshold, tx.db.redact)
+		tx.setErr(err)
+		end()
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, db: tx.db, ctx: ctx, end: end, sql: sql, args: args, start: start, onErr: tx.setErr}, nil
+}
+
+func (tx *Transaction) QueryRow(ctx context.Context, sql string, args ...any) Row {
+	start := time.Now()
+	ctx, end := metrics.StartSpan(ctx, "tx_query_row")
+	row := tx.tx.QueryRow(ctx, sql, args...)
+	return &instrumentedRow{Row: row, db: tx.db, ctx: ctx, end: end, sql: sql, args: args, start: start, onErr: tx.setErr}
+}
+
+// BeginTransaction runs fn inside a transaction, committing if fn returns
+// true and rolling back otherwise (including if fn panics, in which case
+// the panic is re-thrown after the rollback). It does not retry: callers
+// that want BeginTransactionOpts' retry-on-conflict behavior should pass
+// DefaultTransactionOptions() there instead.
+func (db *DB) BeginTransaction(ctx context.Context, fn func(tx *Transaction) (commit bool)) error {
+	return db.BeginTransactionOpts(ctx, TransactionOptions{}, fn)
+}
+
+// BeginTransactionOpts is BeginTransaction with control over isolation
+// level and automatic retries. When a statement run against tx or the
+// final commit fails with a retryable serialization failure or deadlock
+// (SQLSTATE 40001 / 40P01), fn is re-invoked with a fresh *Transaction, up
+// to opts.MaxRetries times with backoff between attempts, before the final
+// error is returned.
+func (db *DB) BeginTransactionOpts(ctx context.Context, opts TransactionOptions, fn func(tx *Transaction) (commit bool)) error {
+	backoff := opts.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(50*time.Millisecond, 2*time.Second)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+		err := db.runTransaction(ctx, opts.IsolationLevel, fn)
+		if err == nil {
+			return nil
+		}
+		if !isRetryable(err) || attempt == opts.MaxRetries {
+			return err
+		}
+
+		lastErr = err
+		metrics.RecordRetry(ctx)
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt+1, err)
+		}
+
+		select {
+		case <-time.After(backoff(attempt + 1)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+func (db *DB) runTransaction(ctx context.Context, isolation IsolationLevel, fn func(tx *Transaction) (commit bool)) (err error) {
+	ctx, end := metrics.StartSpan(ctx, "transaction")
+	defer end()
+
+	backendTx, err := db.backend.BeginTransaction(ctx)
+	if err != nil {
+		return xerrors.Errorf("sturdydb: begin transaction: %w", err)
+	}
+	tx := &Transaction{tx: backendTx, ctx: ctx, db: db}
+
+	if isolation != "" && db.backend.SupportsIsolationLevels() {
+		if err := tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL "+string(isolation)); err != nil {
+			_ = backendTx.Rollback()
+			return xerrors.Errorf("sturdydb: set isolation level: %w", err)
+		}
+	}
+
+	metrics.RecordTxOpen(ctx)
+	defer metrics.RecordTxClose(ctx)
+
+	commit := false
+	defer func() {
+		if p := recover(); p != nil {
+			_ = backendTx.Rollback()
+			metrics.RecordRollback(ctx)
+			panic(p)
+		}
+		if !commit && err == nil {
+			if rbErr := backendTx.Rollback(); rbErr != nil {
+				log.Errorw("sturdydb: rollback failed", "error", rbErr)
+			}
+			metrics.RecordRollback(ctx)
+		}
+	}()
+
+	commit = fn(tx)
+	if stmtErr := tx.firstErr(); stmtErr != nil {
+		commit = false
+		if rbErr := backendTx.Rollback(); rbErr != nil {
+			log.Errorw("sturdydb: rollback failed", "error", rbErr)
+		}
+		metrics.RecordRollback(ctx)
+		return stmtErr
+	}
+	if !commit {
+		return nil
+	}
+	if cErr := backendTx.Commit(); cErr != nil {
+		commit = false
+		return xerrors.Errorf("sturdydb: commit: %w", cErr)
+	}
+	metrics.RecordCommit(ctx)
+	return nil
+}
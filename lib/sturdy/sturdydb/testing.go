@@ -0,0 +1,38 @@
+package sturdydb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// TestConfig returns a config.SturdyDB for an in-process sqlite database
+// unique to the calling test. sqlite isolates tests by file rather than by
+// schema, so, unlike the postgres-wire drivers, it has no use for ITest and
+// leaves it unset.
+func TestConfig(t *testing.T) config.SturdyDB {
+	t.Helper()
+	return config.SturdyDB{
+		Driver:   "sqlite",
+		Database: filepath.Join(t.TempDir(), "sturdy-test.db"),
+	}
+}
+
+// NewTestBackend returns a *DB backed by an in-process sqlite database
+// unique to the calling test. It requires no external services, unlike
+// config.SturdyDB's default YugabyteDB driver, and is torn down
+// automatically via t.Cleanup.
+func NewTestBackend(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := NewFromConfig(TestConfig(t))
+	if err != nil {
+		t.Fatalf("sturdydb: failed to start test backend: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
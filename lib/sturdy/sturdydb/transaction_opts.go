@@ -0,0 +1,68 @@
+package sturdydb
+
+import (
+	"math/rand"
+	"time"
+)
+
+// IsolationLevel mirrors the SQL standard isolation levels understood by
+// the Postgres-wire drivers (YugabyteDB, Postgres, Cockroach). Backends
+// that don't support selecting an isolation level (sqlite, which always
+// runs SERIALIZABLE) skip it entirely rather than erroring; see
+// Backend.SupportsIsolationLevels.
+type IsolationLevel string
+
+const (
+	IsolationDefault        IsolationLevel = ""
+	IsolationReadCommitted  IsolationLevel = "READ COMMITTED"
+	IsolationRepeatableRead IsolationLevel = "REPEATABLE READ"
+	IsolationSerializable   IsolationLevel = "SERIALIZABLE"
+)
+
+// BackoffPolicy computes the delay before the (1-indexed) retry attempt.
+type BackoffPolicy func(attempt int) time.Duration
+
+// ExponentialBackoff returns a BackoffPolicy that doubles base on each
+// attempt up to max, with up to 50% random jitter added so a conflict storm
+// doesn't retry every caller in lockstep.
+func ExponentialBackoff(base, max time.Duration) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		d := base << (attempt - 1)
+		if d <= 0 || d > max {
+			d = max
+		}
+		return d + time.Duration(rand.Int63n(int64(d)/2+1))
+	}
+}
+
+// TransactionOptions configures BeginTransactionOpts.
+type TransactionOptions struct {
+	// MaxRetries is the number of additional attempts made after a
+	// transaction fails with a retryable serialization failure or
+	// deadlock (SQLSTATE 40001 / 40P01). Zero disables retries, which is
+	// also the behavior of the plain BeginTransaction.
+	MaxRetries int
+
+	// IsolationLevel requests a non-default isolation level from the
+	// backend, where supported.
+	IsolationLevel IsolationLevel
+
+	// Backoff computes the delay before each retry. Defaults to
+	// ExponentialBackoff(50ms, 2s) when nil.
+	Backoff BackoffPolicy
+
+	// OnRetry, if set, is called after a retryable failure and before the
+	// backoff sleep, so callers (e.g. the sealing pipeline) can log
+	// conflict storms.
+	OnRetry func(attempt int, err error)
+}
+
+// DefaultTransactionOptions returns options with retries enabled, for
+// callers that expect contention against Yugabyte/Cockroach as a matter of
+// course rather than an exception.
+func DefaultTransactionOptions() TransactionOptions {
+	return TransactionOptions{
+		MaxRetries: 3,
+		Backoff:    ExponentialBackoff(50*time.Millisecond, 2*time.Second),
+	}
+}
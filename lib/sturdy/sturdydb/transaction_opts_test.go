@@ -0,0 +1,44 @@
+package sturdydb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	base := 50 * time.Millisecond
+	max := 2 * time.Second
+	backoff := ExponentialBackoff(base, max)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := backoff(attempt)
+		if d < base {
+			t.Fatalf("attempt %d: backoff %s below base %s", attempt, d, base)
+		}
+		// Jitter adds up to 50% of the doubled base, so the delay should
+		// never exceed max by more than that.
+		if d > max+max/2 {
+			t.Fatalf("attempt %d: backoff %s exceeds max+jitter %s", attempt, d, max+max/2)
+		}
+	}
+}
+
+func TestExponentialBackoffDoubles(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, time.Hour)
+
+	// With max effectively unreachable, each attempt's minimum (jitter-free)
+	// delay should double the last.
+	var prevMin time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		want := 10 * time.Millisecond << (attempt - 1)
+		if want < prevMin {
+			t.Fatalf("attempt %d: expected delay to grow, got floor %s after %s", attempt, want, prevMin)
+		}
+		prevMin = want
+
+		d := backoff(attempt)
+		if d < want {
+			t.Fatalf("attempt %d: backoff %s below expected floor %s", attempt, d, want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package sturdydb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeBackend is a minimal Backend whose BeginTransaction hands out
+// fakeBackendTxs from a caller-supplied queue, for exercising
+// BeginTransactionOpts' retry state machine without a real database.
+type fakeBackend struct {
+	commitErrs []error // consumed one per transaction attempt
+}
+
+func (f *fakeBackend) nextTx() *fakeBackendTx {
+	var err error
+	if len(f.commitErrs) > 0 {
+		err = f.commitErrs[0]
+		f.commitErrs = f.commitErrs[1:]
+	}
+	return &fakeBackendTx{commitErr: err}
+}
+
+func (f *fakeBackend) Exec(ctx context.Context, sql string, args ...any) error { return nil }
+func (f *fakeBackend) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	return nil, nil
+}
+func (f *fakeBackend) QueryRow(ctx context.Context, sql string, args ...any) Row { return nil }
+func (f *fakeBackend) Select(ctx context.Context, dest any, sql string, args ...any) error {
+	return nil
+}
+func (f *fakeBackend) BeginTransaction(ctx context.Context) (BackendTx, error) {
+	return f.nextTx(), nil
+}
+func (f *fakeBackend) TryAdvisoryLock(ctx context.Context, key int64) (bool, func() error, error) {
+	return true, func() error { return nil }, nil
+}
+func (f *fakeBackend) SupportsIsolationLevels() bool { return true }
+func (f *fakeBackend) Close() error                  { return nil }
+
+// fakeBackendTx commits with whatever error it was constructed with, and
+// otherwise no-ops.
+type fakeBackendTx struct {
+	commitErr error
+}
+
+func (t *fakeBackendTx) Exec(ctx context.Context, sql string, args ...any) error { return nil }
+func (t *fakeBackendTx) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	return nil, nil
+}
+func (t *fakeBackendTx) QueryRow(ctx context.Context, sql string, args ...any) Row { return nil }
+func (t *fakeBackendTx) Commit() error                                             { return t.commitErr }
+func (t *fakeBackendTx) Rollback() error                                           { return nil }
+
+func serializationFailure() error {
+	return &pgconn.PgError{Code: sqlstateSerializationFailure}
+}
+
+func TestBeginTransactionOptsRetriesUntilSuccess(t *testing.T) {
+	backend := &fakeBackend{commitErrs: []error{serializationFailure(), serializationFailure(), nil}}
+	db := &DB{backend: backend}
+
+	var attempts int
+	var retriesSeen []int
+	opts := TransactionOptions{
+		MaxRetries: 5,
+		Backoff:    func(attempt int) time.Duration { return 0 },
+		OnRetry: func(attempt int, err error) {
+			retriesSeen = append(retriesSeen, attempt)
+		},
+	}
+
+	err := db.BeginTransactionOpts(context.Background(), opts, func(tx *Transaction) (commit bool) {
+		attempts++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected fn to run 3 times (2 failures + 1 success), got %d", attempts)
+	}
+	if want := []int{1, 2}; !equalInts(retriesSeen, want) {
+		t.Fatalf("expected OnRetry attempts %v, got %v", want, retriesSeen)
+	}
+}
+
+func TestBeginTransactionOptsReturnsFinalErrorAfterMaxRetries(t *testing.T) {
+	backend := &fakeBackend{commitErrs: []error{serializationFailure(), serializationFailure(), serializationFailure()}}
+	db := &DB{backend: backend}
+
+	var attempts int
+	opts := TransactionOptions{
+		MaxRetries: 2,
+		Backoff:    func(attempt int) time.Duration { return 0 },
+	}
+
+	err := db.BeginTransactionOpts(context.Background(), opts, func(tx *Transaction) (commit bool) {
+		attempts++
+		return true
+	})
+	if err == nil {
+		t.Fatal("expected the final retryable error to be returned, got nil")
+	}
+	if !isRetryable(err) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected fn to run 3 times (1 initial + 2 retries), got %d", attempts)
+	}
+}
+
+func TestBeginTransactionOptsDoesNotRetryNonRetryableError(t *testing.T) {
+	backend := &fakeBackend{commitErrs: []error{&pgconn.PgError{Code: "23505"}}}
+	db := &DB{backend: backend}
+
+	var attempts int
+	opts := TransactionOptions{
+		MaxRetries: 5,
+		Backoff:    func(attempt int) time.Duration { return 0 },
+	}
+
+	err := db.BeginTransactionOpts(context.Background(), opts, func(tx *Transaction) (commit bool) {
+		attempts++
+		return true
+	})
+	if err == nil {
+		t.Fatal("expected the non-retryable error to be returned")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected fn to run exactly once, got %d", attempts)
+	}
+}
+
+// TestBeginTransactionOptsRetriesMidTransactionError confirms a retryable
+// error raised by a statement inside fn (not just by Commit) drives a retry,
+// since fn itself can't report an error through its bool return.
+func TestBeginTransactionOptsRetriesMidTransactionError(t *testing.T) {
+	backend := &fakeBackend{commitErrs: []error{nil, nil}}
+	db := &DB{backend: backend}
+
+	var attempts int
+	opts := TransactionOptions{
+		MaxRetries: 2,
+		Backoff:    func(attempt int) time.Duration { return 0 },
+	}
+
+	err := db.BeginTransactionOpts(context.Background(), opts, func(tx *Transaction) (commit bool) {
+		attempts++
+		if attempts == 1 {
+			tx.setErr(serializationFailure())
+			return true
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected fn to run twice (1 mid-tx failure + 1 success), got %d", attempts)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package config
+
+import "time"
+
+// SturdyDB configures the connection used by lib/sturdy/sturdydb.DB, the
+// relational store backing Lotus's sealing-pipeline state.
+type SturdyDB struct {
+	// Driver selects the backend implementation to use. Supported values
+	// are "yugabyte" (the default, kept for backward compatibility),
+	// "postgres", "cockroach", and "sqlite". Drivers are registered by
+	// name in lib/sturdy/sturdydb so single-node operators and tests don't
+	// have to pull in a distributed database they don't need.
+	Driver string
+
+	// Hosts is the list of database hosts to connect to. Multiple hosts
+	// are tried in order until one accepts the connection; it is ignored
+	// by the sqlite driver.
+	Hosts []string
+
+	// Port is the port shared by all Hosts.
+	Port string
+
+	Username string
+	Password string
+
+	// Database is the database name for the postgres-wire drivers, or the
+	// file path (or ":memory:") for the sqlite driver.
+	Database string
+
+	// AutoMigrate runs any pending sturdydb/migrate migrations on startup,
+	// via DB.Migrate, rather than requiring an operator to run
+	// `lotus-miner sturdy migrate up` out of band.
+	AutoMigrate bool
+
+	// ITest is set by integration tests to name a per-test schema that
+	// DB.ITestDeleteAll drops on teardown. sturdydb neither creates that
+	// schema nor directs queries into it. Production deployments should
+	// leave this empty.
+	ITest string
+
+	// SlowQueryThreshold is the duration after which a query is logged at
+	// WARN with its SQL, args and stack. Zero uses
+	// metrics.DefaultSlowQueryThreshold.
+	SlowQueryThreshold time.Duration
+}
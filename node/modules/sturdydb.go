@@ -0,0 +1,13 @@
+package modules
+
+import (
+	"github.com/filecoin-project/lotus/lib/sturdy/sturdydb"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// NewSturdyDB is the fx constructor for *sturdydb.DB, for use as:
+//
+//	Override(new(*sturdydb.DB), modules.NewSturdyDB)
+func NewSturdyDB(cfg config.SturdyDB) (*sturdydb.DB, error) {
+	return sturdydb.NewFromConfig(cfg)
+}